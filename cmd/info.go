@@ -15,17 +15,24 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/url"
 	"os"
-	"os/exec"
 	"os/user"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"text/template"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/xtaraim/promu/config"
+	"github.com/xtaraim/promu/source"
+	"github.com/xtaraim/promu/vcs"
 )
 
 // infoCmd represents the info command
@@ -38,34 +45,44 @@ var infoCmd = &cobra.Command{
 	},
 }
 
+var (
+	infoFormat   string
+	infoTemplate string
+)
+
 // init prepares cobra flags
 func init() {
+	infoCmd.Flags().StringVar(&infoFormat, "format", "text", "Output format: text, json, yaml, go-template")
+	infoCmd.Flags().StringVar(&infoTemplate, "template", "", "Go template string, evaluated against the project info when --format=go-template")
 	Promu.AddCommand(infoCmd)
 }
 
 // ProjectInfo represents current project useful informations.
 type ProjectInfo struct {
-	Branch   string
-	Name     string
-	Owner    string
-	Repo     string
-	Revision string
-	Version  string
+	Branch        string
+	Name          string
+	Namespace     []string
+	Owner         string
+	Repo          string
+	Revision      string
+	VCS           string
+	Version       string
+	VersionSource VersionSource
+	SourceURL     string
 }
 
 // NewProjectInfo returns a new ProjectInfo.
 func NewProjectInfo() (ProjectInfo, error) {
 	projectInfo := ProjectInfo{}
 
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-	if err := cmd.Run(); err != nil {
-		repo, err := os.Getwd()
-		if err != nil {
-			return projectInfo, errors.Wrapf(err, "Couldn't get current working directory")
-		}
-		repo = strings.TrimPrefix(repo, os.Getenv("GOPATH"))
+	dir, err := os.Getwd()
+	if err != nil {
+		return projectInfo, errors.Wrapf(err, "Couldn't get current working directory")
+	}
+
+	backend := vcs.Detect(dir)
+	if backend == nil {
+		repo := strings.TrimPrefix(dir, os.Getenv("GOPATH"))
 		repo = strings.TrimPrefix(repo, "/src/")
 
 		user, err := user.Current()
@@ -79,55 +96,163 @@ func NewProjectInfo() (ProjectInfo, error) {
 			Owner:    user.Username,
 			Repo:     repo,
 			Revision: "non-git",
+			VCS:      "none",
 		}
 	} else {
-		repo, err := repoLocation()
+		remoteURL, err := backend.RemoteURL()
+		if err != nil {
+			return projectInfo, errors.Wrapf(err, "Couldn't determine %s remote URL", backend.Name())
+		}
+		repo, err := repoLocation(remoteURL)
 		if err != nil {
 			return projectInfo, errors.Wrapf(err, "Couldn't parse repo location")
 		}
+		branch, err := backend.Branch()
+		if err != nil {
+			return projectInfo, errors.Wrapf(err, "Couldn't determine %s branch", backend.Name())
+		}
+		revision, err := backend.Revision()
+		if err != nil {
+			return projectInfo, errors.Wrapf(err, "Couldn't determine %s revision", backend.Name())
+		}
+
+		namespace, owner, name := splitRepoPath(repo)
 		projectInfo = ProjectInfo{
-			Branch:   shellOutput("git rev-parse --abbrev-ref HEAD"),
-			Name:     filepath.Base(repo),
-			Owner:    filepath.Base(filepath.Dir(repo)),
-			Repo:     repo,
-			Revision: shellOutput("git rev-parse HEAD"),
+			Branch:    branch,
+			Name:      name,
+			Namespace: namespace,
+			Owner:     owner,
+			Repo:      repo,
+			Revision:  revision,
+			VCS:       backend.Name(),
 		}
 	}
 
-	version, err := findVersion()
+	importPath := projectInfo.Repo
+	if cfg := loadRepositoryConfig(); cfg != nil && cfg.Repository.Path != "" {
+		importPath = cfg.Repository.Path
+	}
+
+	version, versionSource, err := findVersion(importPath, backend)
 	if err != nil {
 		warn(errors.Wrap(err, "Unable to find project's version"))
 	} else {
 		projectInfo.Version = version
+		projectInfo.VersionSource = versionSource
+	}
+
+	if host := strings.SplitN(projectInfo.Repo, "/", 2)[0]; host != "" {
+		registry, err := source.NewRegistry(loadRepositoryConfig())
+		if err != nil {
+			warn(errors.Wrap(err, "Couldn't load source_templates"))
+		} else if sourceURL, err := registry.RootURL(source.Info{
+			Host:  host,
+			Owner: projectInfo.Owner,
+			Repo:  projectInfo.Name,
+			Rev:   projectInfo.Revision,
+		}); err == nil {
+			projectInfo.SourceURL = sourceURL
+		}
 	}
 
 	return projectInfo, nil
 }
 
 func runInfo() {
+	switch infoFormat {
+	case "text":
+		printInfoText()
+	case "json":
+		printInfoJSON()
+	case "yaml":
+		printInfoYAML()
+	case "go-template":
+		printInfoTemplate()
+	default:
+		fatal(errors.Errorf("Unknown --format %q: must be one of text, json, yaml, go-template", infoFormat))
+	}
+}
+
+func printInfoText() {
 	fmt.Println("Name:", info.Name)
 	fmt.Println("Version:", info.Version)
+	fmt.Println("Namespace:", strings.Join(info.Namespace, "/"))
 	fmt.Println("Owner:", info.Owner)
 	fmt.Println("Repo:", info.Repo)
 	fmt.Println("Branch:", info.Branch)
 	fmt.Println("Revision:", info.Revision)
+	fmt.Println("VCS:", info.VCS)
+	fmt.Println("VersionSource:", info.VersionSource)
+	fmt.Println("SourceURL:", info.SourceURL)
+}
+
+func printInfoJSON() {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		fatal(errors.Wrap(err, "Couldn't marshal project info as JSON"))
+		return
+	}
+	fmt.Println(string(data))
+}
+
+func printInfoYAML() {
+	data, err := yaml.Marshal(info)
+	if err != nil {
+		fatal(errors.Wrap(err, "Couldn't marshal project info as YAML"))
+		return
+	}
+	fmt.Print(string(data))
+}
+
+func printInfoTemplate() {
+	if infoTemplate == "" {
+		fatal(errors.New("--format=go-template requires --template"))
+		return
+	}
+
+	tmpl, err := template.New("info").Parse(infoTemplate)
+	if err != nil {
+		fatal(errors.Wrap(err, "Couldn't parse --template"))
+		return
+	}
+	if err := tmpl.Execute(os.Stdout, info); err != nil {
+		fatal(errors.Wrap(err, "Couldn't execute --template"))
+		return
+	}
+	fmt.Println()
 }
 
 // Convert SCP-like URL to SSH URL(e.g. [user@]host.xz:path/to/repo.git/)
 // ref. http://git-scm.com/docs/git-fetch#_git_urls
 // (golang hasn't supported Perl-like negative look-behind match)
+//
+// The host group also accepts a bracketed IPv6 literal (e.g. [::1]), and an
+// optional numeric port may appear between the host and the path, as in
+// `git@host:2222:org/repo.git`. The path group excludes a leading backslash
+// so that Windows paths (C:\Users\...) aren't mistaken for scp-like URLs.
 var hasSchemePattern = regexp.MustCompile("^[^:]+://")
-var scpLikeUrlPattern = regexp.MustCompile("^([^@]+@)?([^:]+):/?(.+)$")
+var scpLikeURLPattern = regexp.MustCompile(`^(?:(?P<user>[^@\s]+)@)?(?P<host>\[[0-9a-fA-F:]+\]|[^:\s]+):(?:(?P<port>[0-9]{1,5}):)?(?P<path>[^\\].*)$`)
 
-func repoLocation() (string, error) {
-	repo := shellOutput("git config --get remote.origin.url")
+func repoLocation(repo string) (string, error) {
+	if !hasSchemePattern.MatchString(repo) {
+		if matched := scpLikeURLPattern.FindStringSubmatch(repo); matched != nil {
+			groups := make(map[string]string, len(matched))
+			for i, name := range scpLikeURLPattern.SubexpNames() {
+				if name != "" {
+					groups[name] = matched[i]
+				}
+			}
 
-	if !hasSchemePattern.MatchString(repo) && scpLikeUrlPattern.MatchString(repo) {
-		matched := scpLikeUrlPattern.FindStringSubmatch(repo)
-		user := matched[1]
-		host := matched[2]
-		path := matched[3]
-		repo = fmt.Sprintf("ssh://%s%s/%s", user, host, path)
+			user := ""
+			if groups["user"] != "" {
+				user = groups["user"] + "@"
+			}
+			host := groups["host"]
+			if groups["port"] != "" {
+				host = host + ":" + groups["port"]
+			}
+			repo = fmt.Sprintf("ssh://%s%s/%s", user, host, strings.TrimPrefix(groups["path"], "/"))
+		}
 	}
 
 	u, err := url.Parse(repo)
@@ -135,17 +260,95 @@ func repoLocation() (string, error) {
 		return "", err
 	}
 
-	repo = fmt.Sprintf("%s%s", strings.Split(u.Host, ":")[0], u.Path)
+	// A single-letter "scheme" followed by a backslash (e.g. a Windows path
+	// like `C:\Users\alice\repo`) parses as an opaque URL rather than
+	// erroring: url.Parse has no way to know it isn't a real scheme. Treat
+	// that, and any other opaque result, as unparseable rather than
+	// silently collapsing Host+Path to "".
+	if u.Opaque != "" {
+		return "", errors.Errorf("Couldn't parse %q as a repository location", repo)
+	}
+
+	// file:// URLs have no host; fall back to the path itself, without its
+	// leading slash, rather than prefixing an empty host. Bare local paths
+	// are not rewritten to file:// and keep their leading slash.
+	if u.Host == "" && u.Scheme == "file" {
+		repo = strings.TrimPrefix(u.Path, "/")
+	} else if u.Host == "" && u.Path == "" {
+		return "", errors.Errorf("Couldn't parse %q as a repository location", repo)
+	} else {
+		repo = fmt.Sprintf("%s%s", u.Hostname(), u.Path)
+	}
 	repo = strings.TrimSuffix(repo, ".git")
 	return repo, nil
 }
 
-func findVersion() (string, error) {
-	var files = []string{"VERSION", "version/VERSION"}
-	for _, file := range files {
-		if fileExists(file) {
-			return readFile(file), nil
+// defaultHostNamespaceDepth lists how many leading path segments (not
+// counting the repository name itself) make up a known host's
+// owner/namespace. -1 means "use every remaining segment", which fits hosts
+// that allow arbitrarily nested groups, such as GitLab subgroups or Gitea
+// organizations/teams.
+var defaultHostNamespaceDepth = map[string]int{
+	"github.com":    1,
+	"bitbucket.org": 1,
+	"gitlab.com":    -1,
+}
+
+// defaultNamespaceDepth is used for hosts that have neither a built-in nor a
+// `.promu.yml`-configured depth.
+const defaultNamespaceDepth = 1
+
+// hostNamespaceDepth resolves the namespace depth to use for host, preferring
+// a `.promu.yml` `repository.host_namespace_depth` override over the
+// built-in defaults.
+func hostNamespaceDepth(host string, cfg *config.Config) int {
+	if cfg != nil {
+		if depth, ok := cfg.Repository.HostNamespaceDepth[host]; ok {
+			return depth
 		}
 	}
-	return "", errors.New("missing `VERSION` or `version/VERSION` file")
+	if depth, ok := defaultHostNamespaceDepth[host]; ok {
+		return depth
+	}
+	return defaultNamespaceDepth
+}
+
+// splitRepoPath splits a `host/path/to/repo` string (as returned by
+// repoLocation) into its namespace segments, joined owner and repository
+// name, using hostNamespaceDepth to decide where the namespace ends and the
+// repository name begins.
+func splitRepoPath(repo string) (namespace []string, owner, name string) {
+	segments := strings.Split(repo, "/")
+	if len(segments) < 2 {
+		return nil, "", repo
+	}
+	host := segments[0]
+	path := segments[1:]
+
+	depth := hostNamespaceDepth(host, loadRepositoryConfig())
+	if depth < 0 || depth > len(path)-1 {
+		depth = len(path) - 1
+	}
+
+	namespace = path[:depth]
+	name = strings.Join(path[depth:], "/")
+	owner = strings.Join(namespace, "/")
+	return namespace, owner, name
+}
+
+// loadRepositoryConfig reads `.promu.yml` from the current directory, if
+// present, and returns its parsed Config. It returns nil when the file is
+// missing so callers can fall back to built-in defaults.
+func loadRepositoryConfig() *config.Config {
+	data, err := ioutil.ReadFile(".promu.yml")
+	if err != nil {
+		return nil
+	}
+
+	var cfg config.Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		warn(errors.Wrap(err, "Couldn't parse .promu.yml"))
+		return nil
+	}
+	return &cfg
 }
@@ -0,0 +1,133 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "testing"
+
+// Canonical forms taken from git's own urls.txt (ssh, git, http[s], ftp[s],
+// file, scp-like with/without user, with/without port), plus the IPv6 and
+// Windows-path edge cases that motivated hardening scpLikeURLPattern.
+func TestRepoLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "ssh with user and port",
+			repo: "ssh://user@host.xz:2222/path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "ssh without user or port",
+			repo: "ssh://host.xz/path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "ssh with tilde path",
+			repo: "ssh://host.xz/~user/path/to/repo.git",
+			want: "host.xz/~user/path/to/repo",
+		},
+		{
+			name: "scp-like with user",
+			repo: "user@host.xz:path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "scp-like without user",
+			repo: "host.xz:path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "scp-like with user and leading slash in path",
+			repo: "user@host.xz:/path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "scp-like with explicit port",
+			repo: "git@host:22:org/repo.git",
+			want: "host/org/repo",
+		},
+		{
+			name: "scp-like IPv6 literal",
+			repo: "[::1]:repo.git",
+			want: "::1/repo",
+		},
+		{
+			name: "git scheme",
+			repo: "git://host.xz/path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "http scheme",
+			repo: "http://host.xz/path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "https scheme",
+			repo: "https://host.xz/path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "ftp scheme",
+			repo: "ftp://host.xz/path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "ftps scheme",
+			repo: "ftps://host.xz/path/to/repo.git",
+			want: "host.xz/path/to/repo",
+		},
+		{
+			name: "file scheme, absolute path",
+			repo: "file:///path/to/repo.git",
+			want: "path/to/repo",
+		},
+		{
+			name: "absolute local path, no scheme",
+			repo: "/path/to/repo.git",
+			want: "/path/to/repo",
+		},
+		{
+			name: "relative local path, no scheme",
+			repo: "path/to/repo.git",
+			want: "path/to/repo",
+		},
+		{
+			name:    "windows path is not a valid git URL",
+			repo:    `C:\Users\alice\repo`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repoLocation(tt.repo)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("repoLocation(%q) = %q, nil; want an error", tt.repo, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("repoLocation(%q) returned unexpected error: %v", tt.repo, err)
+			}
+			if got != tt.want {
+				t.Errorf("repoLocation(%q) = %q; want %q", tt.repo, got, tt.want)
+			}
+		})
+	}
+}
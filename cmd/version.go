@@ -0,0 +1,273 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/semver"
+
+	"github.com/xtaraim/promu/vcs"
+)
+
+// VersionSource identifies which provider supplied a ProjectInfo's Version.
+type VersionSource string
+
+const (
+	// VersionSourceFile means the version came from a VERSION or
+	// version/VERSION file.
+	VersionSourceFile VersionSource = "file"
+	// VersionSourceConfig means the version came from `.promu.yml`'s
+	// `version:` field.
+	VersionSourceConfig VersionSource = "config"
+	// VersionSourceGitTag means the version came from the working tree's
+	// own tags, via `git describe`.
+	VersionSourceGitTag VersionSource = "git-tag"
+	// VersionSourceGitRemoteTag means the version came from the origin
+	// remote's tags, via `git ls-remote --tags`.
+	VersionSourceGitRemoteTag VersionSource = "git-remote-tag"
+	// VersionSourceVCSTag means the version came from the working copy's
+	// own tags, via the generic vcs.VCS.Tags backend (hg, bzr, fossil; and
+	// git when neither `git describe` nor `git ls-remote` yielded a
+	// version). Subversion reports no tags, so it never produces this.
+	VersionSourceVCSTag VersionSource = "vcs-tag"
+	// VersionSourceGopkgLock means the version came from the project's own
+	// entry in Gopkg.lock.
+	VersionSourceGopkgLock VersionSource = "Gopkg.lock"
+	// VersionSourceGoMod means the version came from go.mod, either a
+	// `// +version` pragma or the module path's major-version suffix.
+	VersionSourceGoMod VersionSource = "go.mod"
+)
+
+// versionProviders lists every known way of discovering a project's version,
+// tried in order. The first one to return a valid semantic version wins. A
+// provider returning an error only rules itself out; it does not abort the
+// rest of the chain.
+var versionProviders = []struct {
+	source VersionSource
+	find   func(importPath string, backend vcs.VCS) (string, error)
+}{
+	{VersionSourceFile, func(string, vcs.VCS) (string, error) { return versionFromFile() }},
+	{VersionSourceConfig, func(string, vcs.VCS) (string, error) { return versionFromConfig() }},
+	{VersionSourceGitTag, func(_ string, backend vcs.VCS) (string, error) { return versionFromGitDescribe(backend) }},
+	{VersionSourceGitRemoteTag, func(_ string, backend vcs.VCS) (string, error) { return versionFromGitLsRemote(backend) }},
+	{VersionSourceVCSTag, func(_ string, backend vcs.VCS) (string, error) { return versionFromVCSTags(backend) }},
+	{VersionSourceGopkgLock, func(importPath string, _ vcs.VCS) (string, error) { return versionFromGopkgLock(importPath) }},
+	{VersionSourceGoMod, func(string, vcs.VCS) (string, error) { return versionFromGoMod() }},
+}
+
+// findVersion tries every versionProvider in order and returns the first
+// candidate that is a valid semantic version, along with the provider that
+// produced it. importPath is the project's own Go import path (as known so
+// far from ProjectInfo.Repo), used to spot its self-reference in
+// Gopkg.lock; backend is the detected VCS working copy, or nil outside one.
+//
+// A provider erroring out (a malformed Gopkg.lock, a VCS command failing)
+// only eliminates that one candidate; it does not stop the remaining,
+// lower-priority providers from being tried.
+func findVersion(importPath string, backend vcs.VCS) (string, VersionSource, error) {
+	for _, provider := range versionProviders {
+		version, err := provider.find(importPath, backend)
+		if err != nil || version == "" || !isValidSemver(version) {
+			continue
+		}
+		return version, provider.source, nil
+	}
+
+	return "", "", errors.New("missing `VERSION` or `version/VERSION` file, `.promu.yml` `version:`, VCS tags, `Gopkg.lock` or `go.mod` version")
+}
+
+// isValidSemver reports whether version is a valid semantic version,
+// tolerating a missing leading "v" the way most VERSION files are written.
+func isValidSemver(version string) bool {
+	return semver.IsValid(withVPrefix(version))
+}
+
+// withVPrefix adds the "v" prefix golang.org/x/mod/semver requires, unless
+// version already has one.
+func withVPrefix(version string) string {
+	if strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
+
+func versionFromFile() (string, error) {
+	for _, file := range []string{"VERSION", "version/VERSION"} {
+		if fileExists(file) {
+			return strings.TrimSpace(readFile(file)), nil
+		}
+	}
+	return "", nil
+}
+
+func versionFromConfig() (string, error) {
+	cfg := loadRepositoryConfig()
+	if cfg == nil {
+		return "", nil
+	}
+	return cfg.Version, nil
+}
+
+// versionFromGitDescribe resolves the current git tag via
+// `git describe --tags --abbrev=0`, appending a `-<count>-g<hash>`
+// pre-release suffix when HEAD isn't exactly on that tag. It only applies
+// to git working copies; versionFromVCSTags covers every other backend.
+func versionFromGitDescribe(backend vcs.VCS) (string, error) {
+	if backend == nil || backend.Name() != "git" {
+		return "", nil
+	}
+
+	tag := shellOutput("git describe --tags --abbrev=0")
+	if tag == "" {
+		return "", nil
+	}
+
+	count := shellOutput(fmt.Sprintf("git rev-list %s..HEAD --count", tag))
+	if count == "" || count == "0" {
+		return tag, nil
+	}
+
+	hash := shellOutput("git rev-parse --short HEAD")
+	if hash == "" {
+		return tag, nil
+	}
+
+	return fmt.Sprintf("%s-%s-g%s", tag, count, hash), nil
+}
+
+// derefTagSuffix marks a dereferenced annotated tag in `git ls-remote`
+// output (e.g. `refs/tags/v1.2.3^{}`); it points at the same commit as
+// `refs/tags/v1.2.3` and should be skipped to avoid double-counting.
+const derefTagSuffix = "^{}"
+
+// versionFromGitLsRemote picks the semver-max `v*` tag advertised by the
+// origin remote, for git working trees that have no local tags. `git
+// ls-remote` has no equivalent in the vcs.VCS interface, so this remains
+// git-specific.
+func versionFromGitLsRemote(backend vcs.VCS) (string, error) {
+	if backend == nil || backend.Name() != "git" {
+		return "", nil
+	}
+
+	out := shellOutput("git ls-remote --tags origin")
+	if out == "" {
+		return "", nil
+	}
+
+	best := ""
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		ref := fields[1]
+		if strings.HasSuffix(ref, derefTagSuffix) {
+			continue
+		}
+
+		tag := strings.TrimPrefix(ref, "refs/tags/")
+		if !strings.HasPrefix(tag, "v") || !semver.IsValid(tag) {
+			continue
+		}
+		if best == "" || semver.Compare(tag, best) > 0 {
+			best = tag
+		}
+	}
+	return best, nil
+}
+
+// versionFromVCSTags picks the semver-max tag reported by backend.Tags, the
+// one piece of version discovery that's genuinely VCS-agnostic: it runs the
+// same way whether the working copy is git, hg, svn, bzr or fossil.
+func versionFromVCSTags(backend vcs.VCS) (string, error) {
+	if backend == nil {
+		return "", nil
+	}
+
+	tags, err := backend.Tags()
+	if err != nil {
+		return "", nil
+	}
+
+	best := ""
+	for _, tag := range tags {
+		if !isValidSemver(tag) {
+			continue
+		}
+		if best == "" || semver.Compare(withVPrefix(tag), withVPrefix(best)) > 0 {
+			best = tag
+		}
+	}
+	return best, nil
+}
+
+var gopkgLockFieldPattern = regexp.MustCompile(`^(name|version)\s*=\s*"(.+)"$`)
+
+// versionFromGopkgLock looks for the project's own `[[projects]]` entry in
+// Gopkg.lock (matched by importPath) and returns its pinned version.
+func versionFromGopkgLock(importPath string) (string, error) {
+	if importPath == "" || !fileExists("Gopkg.lock") {
+		return "", nil
+	}
+
+	var name, version string
+	for _, line := range strings.Split(readFile("Gopkg.lock"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "[[projects]]" {
+			name, version = "", ""
+			continue
+		}
+
+		m := gopkgLockFieldPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		switch m[1] {
+		case "name":
+			name = m[2]
+		case "version":
+			version = m[2]
+		}
+		if name == importPath && version != "" {
+			return version, nil
+		}
+	}
+	return "", nil
+}
+
+var goModVersionPragmaPattern = regexp.MustCompile(`(?m)^//\s*\+version\s+(v\d+\.\d+\.\d+\S*)\s*$`)
+var goModMajorSuffixPattern = regexp.MustCompile(`(?m)^module\s+\S+/v(\d+)\s*$`)
+
+// versionFromGoMod looks for a `// +version vX.Y.Z` pragma anywhere in
+// go.mod, falling back to the module path's `/vN` major-version suffix.
+func versionFromGoMod() (string, error) {
+	if !fileExists("go.mod") {
+		return "", nil
+	}
+	data := readFile("go.mod")
+
+	if m := goModVersionPragmaPattern.FindStringSubmatch(data); m != nil {
+		return m[1], nil
+	}
+	if m := goModMajorSuffixPattern.FindStringSubmatch(data); m != nil {
+		return fmt.Sprintf("v%s.0.0", m[1]), nil
+	}
+	return "", nil
+}
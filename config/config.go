@@ -0,0 +1,112 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config holds the structure of the `.promu.yml` project file.
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config represents the `.promu.yml` project file.
+type Config struct {
+	Repository Repository `yaml:"repository"`
+
+	// Version pins the project's version directly in `.promu.yml`,
+	// overriding any value that would otherwise be derived from the VCS,
+	// Gopkg.lock or go.mod. A VERSION or version/VERSION file still takes
+	// precedence over it.
+	Version string `yaml:"version"`
+
+	// SourceTemplates overrides or adds source-URL templates, keyed by a
+	// regular expression matched against the repository host. This is how
+	// self-hosted Gitea/GitLab/etc. instances, which aren't in the
+	// built-in list, get working "view source" links. Entries are tried in
+	// the order they're declared, so when more than one pattern can match
+	// the same host, the first one written wins.
+	SourceTemplates SourceTemplates `yaml:"source_templates"`
+}
+
+// SourceTemplate holds the URL patterns used to build browseable links into
+// a repository on a given host. Each pattern may reference the placeholders
+// {owner}, {repo}, {rev}, {path} and {line}.
+type SourceTemplate struct {
+	Root string `yaml:"root"` // repository root
+	Dir  string `yaml:"dir"`  // a directory at a revision
+	File string `yaml:"file"` // a file at a revision
+	Line string `yaml:"line"` // a file+line range
+}
+
+// SourceTemplateEntry pairs a `source_templates` host pattern with its
+// Template, in the order it was declared in `.promu.yml`.
+type SourceTemplateEntry struct {
+	HostPattern string
+	Template    SourceTemplate
+}
+
+// SourceTemplates is an ordered list of SourceTemplateEntry. It unmarshals
+// from the same `source_templates: {pattern: template}` YAML mapping a plain
+// `map[string]SourceTemplate` would, but preserves declaration order instead
+// of Go's randomized map iteration order, so consumers like
+// source.Registry.Lookup can deterministically prefer earlier entries over
+// later, overlapping ones.
+type SourceTemplates []SourceTemplateEntry
+
+// UnmarshalYAML decodes a `source_templates` mapping into an ordered
+// SourceTemplates, via yaml.MapSlice, which is the only yaml.v2 type that
+// retains the mapping's on-disk key order.
+func (s *SourceTemplates) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw yaml.MapSlice
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	entries := make(SourceTemplates, 0, len(raw))
+	for _, item := range raw {
+		hostPattern, ok := item.Key.(string)
+		if !ok {
+			return fmt.Errorf("source_templates: key %v is not a string", item.Key)
+		}
+
+		data, err := yaml.Marshal(item.Value)
+		if err != nil {
+			return fmt.Errorf("source_templates: re-encoding %q: %w", hostPattern, err)
+		}
+		var tmpl SourceTemplate
+		if err := yaml.Unmarshal(data, &tmpl); err != nil {
+			return fmt.Errorf("source_templates: decoding %q: %w", hostPattern, err)
+		}
+
+		entries = append(entries, SourceTemplateEntry{HostPattern: hostPattern, Template: tmpl})
+	}
+	*s = entries
+	return nil
+}
+
+// Repository holds repository-related settings read from `.promu.yml`.
+type Repository struct {
+	// Path is the project's canonical Go import path (e.g.
+	// "github.com/prometheus/promu"). It's used to recognize the
+	// project's own entry in files like Gopkg.lock.
+	Path string `yaml:"path"`
+
+	// HostNamespaceDepth maps a git host (e.g. "gitlab.example.com") to the
+	// number of leading path segments that make up its owner/namespace,
+	// not counting the repository name itself. A depth of -1 means "use
+	// every remaining segment", which is the right default for hosts that
+	// support arbitrarily nested groups, such as GitLab subgroups.
+	HostNamespaceDepth map[string]int `yaml:"host_namespace_depth"`
+}
@@ -0,0 +1,204 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package source turns a repository's identity into browseable file and
+// line URLs, the way pkg.go.dev does when it links a symbol back to its
+// host. It's meant to be used wherever promu wants to print or stamp a link
+// back to the code it built from: `promu info`, release notes, and ldflags
+// stamps.
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xtaraim/promu/config"
+)
+
+// Info identifies a repository well enough to build source URLs for it.
+type Info struct {
+	Host  string
+	Owner string
+	Repo  string
+	Rev   string
+}
+
+// Template is the set of URL patterns needed to link into a repository on a
+// given host.
+type Template struct {
+	Root string // repository root, e.g. https://github.com/{owner}/{repo}
+	Dir  string // a directory at a revision
+	File string // a file at a revision
+	Line string // a file+line range
+}
+
+// defaultTemplates covers the hosts promu recognizes out of the box.
+// Self-hosted instances (Gitea, GitLab, ...) are matched through
+// `.promu.yml`'s `source_templates`, or else through the generic Gitea or
+// GitLab template when the host name contains "gitea" or "gitlab", see
+// Registry.Lookup.
+var defaultTemplates = map[string]Template{
+	"github.com": {
+		Root: "https://github.com/{owner}/{repo}",
+		Dir:  "https://github.com/{owner}/{repo}/tree/{rev}/{path}",
+		File: "https://github.com/{owner}/{repo}/blob/{rev}/{path}",
+		Line: "https://github.com/{owner}/{repo}/blob/{rev}/{path}#L{line}",
+	},
+	"gitlab.com": {
+		Root: "https://gitlab.com/{owner}/{repo}",
+		Dir:  "https://gitlab.com/{owner}/{repo}/-/tree/{rev}/{path}",
+		File: "https://gitlab.com/{owner}/{repo}/-/blob/{rev}/{path}",
+		Line: "https://gitlab.com/{owner}/{repo}/-/blob/{rev}/{path}#L{line}",
+	},
+	"bitbucket.org": {
+		Root: "https://bitbucket.org/{owner}/{repo}",
+		Dir:  "https://bitbucket.org/{owner}/{repo}/src/{rev}/{path}",
+		File: "https://bitbucket.org/{owner}/{repo}/src/{rev}/{path}",
+		Line: "https://bitbucket.org/{owner}/{repo}/src/{rev}/{path}#lines-{line}",
+	},
+	"sr.ht": {
+		Root: "https://sr.ht/{owner}/{repo}",
+		Dir:  "https://sr.ht/{owner}/{repo}/tree/{rev}/item/{path}",
+		File: "https://sr.ht/{owner}/{repo}/tree/{rev}/item/{path}",
+		Line: "https://sr.ht/{owner}/{repo}/tree/{rev}/item/{path}#L{line}",
+	},
+	"codeberg.org": {
+		Root: "https://codeberg.org/{owner}/{repo}",
+		Dir:  "https://codeberg.org/{owner}/{repo}/src/commit/{rev}/{path}",
+		File: "https://codeberg.org/{owner}/{repo}/src/commit/{rev}/{path}",
+		Line: "https://codeberg.org/{owner}/{repo}/src/commit/{rev}/{path}#L{line}",
+	},
+}
+
+// genericGiteaTemplate is used for any host that looks like a Gitea
+// instance but has no explicit `source_templates` entry, since Gitea's URL
+// scheme holds steady across self-hosted installs.
+var genericGiteaTemplate = Template{
+	Root: "https://{host}/{owner}/{repo}",
+	Dir:  "https://{host}/{owner}/{repo}/src/commit/{rev}/{path}",
+	File: "https://{host}/{owner}/{repo}/src/commit/{rev}/{path}",
+	Line: "https://{host}/{owner}/{repo}/src/commit/{rev}/{path}#L{line}",
+}
+
+// genericGitLabTemplate is used for any host that looks like a self-hosted
+// GitLab instance but has no explicit `source_templates` entry. Self-hosted
+// GitLab keeps the same "/-/blob" and "/-/tree" URL scheme as gitlab.com, so
+// only the host needs to change.
+var genericGitLabTemplate = Template{
+	Root: "https://{host}/{owner}/{repo}",
+	Dir:  "https://{host}/{owner}/{repo}/-/tree/{rev}/{path}",
+	File: "https://{host}/{owner}/{repo}/-/blob/{rev}/{path}",
+	Line: "https://{host}/{owner}/{repo}/-/blob/{rev}/{path}#L{line}",
+}
+
+// Registry resolves the Template to use for a host, preferring
+// `.promu.yml`-configured overrides over the built-in defaults.
+type Registry struct {
+	overrides []hostTemplate
+}
+
+type hostTemplate struct {
+	pattern *regexp.Regexp
+	tmpl    Template
+}
+
+// NewRegistry builds a Registry from the `source_templates` section of cfg.
+// cfg may be nil, in which case only the built-in defaults apply.
+func NewRegistry(cfg *config.Config) (*Registry, error) {
+	r := &Registry{}
+	if cfg == nil {
+		return r, nil
+	}
+
+	for _, entry := range cfg.SourceTemplates {
+		re, err := regexp.Compile(entry.HostPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source_templates host pattern %q: %w", entry.HostPattern, err)
+		}
+		r.overrides = append(r.overrides, hostTemplate{
+			pattern: re,
+			tmpl: Template{
+				Root: entry.Template.Root,
+				Dir:  entry.Template.Dir,
+				File: entry.Template.File,
+				Line: entry.Template.Line,
+			},
+		})
+	}
+	return r, nil
+}
+
+// Lookup returns the Template configured for host, trying configured
+// overrides first (in the order they were declared in `.promu.yml`, so the
+// first pattern that matches wins, deterministically), then the built-in
+// defaults, then a generic guess for self-hosted instances whose name
+// suggests Gitea or GitLab.
+func (r *Registry) Lookup(host string) (Template, bool) {
+	for _, o := range r.overrides {
+		if o.pattern.MatchString(host) {
+			return o.tmpl, true
+		}
+	}
+	if tmpl, ok := defaultTemplates[host]; ok {
+		return tmpl, true
+	}
+	if strings.Contains(host, "gitea") {
+		return genericGiteaTemplate, true
+	}
+	if strings.Contains(host, "gitlab") {
+		return genericGitLabTemplate, true
+	}
+	return Template{}, false
+}
+
+// RootURL returns the URL of info's repository root.
+func (r *Registry) RootURL(info Info) (string, error) {
+	return r.build(info, "", 0, func(t Template) string { return t.Root })
+}
+
+// DirURL returns the URL of path, a directory, at info's revision.
+func (r *Registry) DirURL(info Info, path string) (string, error) {
+	return r.build(info, path, 0, func(t Template) string { return t.Dir })
+}
+
+// FileURL returns the URL of path, a file, at info's revision.
+func (r *Registry) FileURL(info Info, path string) (string, error) {
+	return r.build(info, path, 0, func(t Template) string { return t.File })
+}
+
+// LineURL returns the URL of path, a file, at info's revision, deep-linked
+// to line.
+func (r *Registry) LineURL(info Info, path string, line int) (string, error) {
+	return r.build(info, path, line, func(t Template) string { return t.Line })
+}
+
+func (r *Registry) build(info Info, path string, line int, pick func(Template) string) (string, error) {
+	tmpl, ok := r.Lookup(info.Host)
+	if !ok {
+		return "", fmt.Errorf("source: no URL template known for host %q", info.Host)
+	}
+
+	s := pick(tmpl)
+	s = strings.ReplaceAll(s, "{host}", info.Host)
+	s = strings.ReplaceAll(s, "{owner}", info.Owner)
+	s = strings.ReplaceAll(s, "{repo}", info.Repo)
+	s = strings.ReplaceAll(s, "{rev}", info.Rev)
+	s = strings.ReplaceAll(s, "{path}", path)
+	if line > 0 {
+		s = strings.ReplaceAll(s, "{line}", strconv.Itoa(line))
+	}
+	return s, nil
+}
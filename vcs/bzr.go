@@ -0,0 +1,65 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs
+
+import "strings"
+
+// Bzr implements VCS for Bazaar working copies.
+type Bzr struct{}
+
+// Name implements VCS.
+func (Bzr) Name() string { return "bzr" }
+
+// Detect implements VCS.
+func (Bzr) Detect(dir string) bool {
+	_, err := run(dir, "bzr", "root")
+	return err == nil
+}
+
+// TopLevel implements VCS.
+func (Bzr) TopLevel() (string, error) {
+	return run("", "bzr", "root")
+}
+
+// Branch implements VCS.
+func (Bzr) Branch() (string, error) {
+	return run("", "bzr", "nick")
+}
+
+// Revision implements VCS.
+func (Bzr) Revision() (string, error) {
+	return run("", "bzr", "revno")
+}
+
+// RemoteURL implements VCS.
+func (Bzr) RemoteURL() (string, error) {
+	return run("", "bzr", "config", "parent_location")
+}
+
+// Tags implements VCS.
+func (Bzr) Tags() ([]string, error) {
+	out, err := run("", "bzr", "tags", "-r", "-1")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for _, line := range splitLines(out) {
+		if name := strings.Fields(line); len(name) > 0 {
+			tags = append(tags, name[0])
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,76 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs
+
+import "strings"
+
+// Fossil implements VCS for Fossil working copies.
+type Fossil struct{}
+
+// Name implements VCS.
+func (Fossil) Name() string { return "fossil" }
+
+// Detect implements VCS.
+func (Fossil) Detect(dir string) bool {
+	_, err := run(dir, "fossil", "info")
+	return err == nil
+}
+
+// TopLevel implements VCS.
+func (Fossil) TopLevel() (string, error) {
+	return fossilInfoItem("local-root")
+}
+
+// Branch implements VCS.
+func (Fossil) Branch() (string, error) {
+	return run("", "fossil", "branch", "current")
+}
+
+// Revision implements VCS.
+func (Fossil) Revision() (string, error) {
+	return fossilInfoItem("checkout")
+}
+
+// RemoteURL implements VCS.
+func (Fossil) RemoteURL() (string, error) {
+	return run("", "fossil", "remote-url")
+}
+
+// Tags implements VCS.
+func (Fossil) Tags() ([]string, error) {
+	out, err := run("", "fossil", "tag", "list", "current")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+// fossilInfoItem extracts the value of a "key: value" line from `fossil
+// info`'s output.
+func fossilInfoItem(key string) (string, error) {
+	out, err := run("", "fossil", "info")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range splitLines(out) {
+		if rest := strings.TrimPrefix(line, key+":"); rest != line {
+			fields := strings.Fields(rest)
+			if len(fields) > 0 {
+				return fields[0], nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,56 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs
+
+// Git implements VCS for git working copies.
+type Git struct{}
+
+// Name implements VCS.
+func (Git) Name() string { return "git" }
+
+// Detect implements VCS.
+func (Git) Detect(dir string) bool {
+	_, err := run(dir, "git", "rev-parse", "--show-toplevel")
+	return err == nil
+}
+
+// TopLevel implements VCS.
+func (Git) TopLevel() (string, error) {
+	return run("", "git", "rev-parse", "--show-toplevel")
+}
+
+// Branch implements VCS.
+func (Git) Branch() (string, error) {
+	return run("", "git", "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// Revision implements VCS.
+func (Git) Revision() (string, error) {
+	return run("", "git", "rev-parse", "HEAD")
+}
+
+// RemoteURL implements VCS.
+func (Git) RemoteURL() (string, error) {
+	return run("", "git", "config", "--get", "remote.origin.url")
+}
+
+// Tags implements VCS.
+func (Git) Tags() ([]string, error) {
+	out, err := run("", "git", "tag", "--points-at", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
@@ -0,0 +1,58 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs
+
+import "strings"
+
+// Hg implements VCS for Mercurial working copies.
+type Hg struct{}
+
+// Name implements VCS.
+func (Hg) Name() string { return "hg" }
+
+// Detect implements VCS.
+func (Hg) Detect(dir string) bool {
+	_, err := run(dir, "hg", "root")
+	return err == nil
+}
+
+// TopLevel implements VCS.
+func (Hg) TopLevel() (string, error) {
+	return run("", "hg", "root")
+}
+
+// Branch implements VCS.
+func (Hg) Branch() (string, error) {
+	return run("", "hg", "branch")
+}
+
+// Revision implements VCS.
+func (Hg) Revision() (string, error) {
+	return run("", "hg", "id", "-i")
+}
+
+// RemoteURL implements VCS.
+func (Hg) RemoteURL() (string, error) {
+	return run("", "hg", "paths", "default")
+}
+
+// Tags implements VCS.
+func (Hg) Tags() ([]string, error) {
+	out, err := run("", "hg", "log", "--rev", ".", "--template", "{tags}")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
@@ -0,0 +1,68 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vcs
+
+import "strings"
+
+// Svn implements VCS for Subversion working copies.
+type Svn struct{}
+
+// Name implements VCS.
+func (Svn) Name() string { return "svn" }
+
+// Detect implements VCS.
+func (Svn) Detect(dir string) bool {
+	_, err := run(dir, "svn", "info")
+	return err == nil
+}
+
+// TopLevel implements VCS.
+func (Svn) TopLevel() (string, error) {
+	return run("", "svn", "info", "--show-item", "wc-root")
+}
+
+// Branch derives a branch name from the working copy's relative URL,
+// recognizing the conventional trunk/branches/<name>/tags/<name> layout.
+// It falls back to "trunk" when no such convention is detected.
+func (Svn) Branch() (string, error) {
+	relURL, err := run("", "svn", "info", "--show-item", "relative-url")
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(strings.TrimPrefix(relURL, "^/"), "/")
+	for i, part := range parts {
+		if (part == "branches" || part == "tags") && i+1 < len(parts) {
+			return parts[i+1], nil
+		}
+	}
+	return "trunk", nil
+}
+
+// Revision implements VCS.
+func (Svn) Revision() (string, error) {
+	return run("", "svn", "info", "--show-item", "revision")
+}
+
+// RemoteURL implements VCS.
+func (Svn) RemoteURL() (string, error) {
+	return run("", "svn", "info", "--show-item", "url")
+}
+
+// Tags implements VCS. Subversion has no notion of a tag pointing at a
+// revision the way git or hg do; tags are just copies of the tree, so we
+// report none rather than guess.
+func (Svn) Tags() ([]string, error) {
+	return nil, nil
+}
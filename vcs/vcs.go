@@ -0,0 +1,86 @@
+// Copyright © 2016 Prometheus Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vcs abstracts over the handful of version-control systems promu
+// cares about when collecting project metadata, so that callers don't need
+// to special-case git.
+package vcs
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// VCS is implemented by each supported version-control backend.
+type VCS interface {
+	// Name returns the backend's canonical name (e.g. "git", "hg").
+	Name() string
+	// Detect reports whether dir is governed by this backend.
+	Detect(dir string) bool
+	// TopLevel returns the absolute path to the working copy's root.
+	TopLevel() (string, error)
+	// Branch returns the current branch name.
+	Branch() (string, error)
+	// Revision returns the current revision identifier.
+	Revision() (string, error)
+	// RemoteURL returns the configured remote/default push location.
+	RemoteURL() (string, error)
+	// Tags returns the tags pointing at the current revision.
+	Tags() ([]string, error)
+}
+
+// All lists every supported backend, probed in this order by Detect.
+var All = []VCS{
+	&Git{},
+	&Hg{},
+	&Svn{},
+	&Bzr{},
+	&Fossil{},
+}
+
+// Detect returns the first backend in All that claims dir, or nil if none
+// do.
+func Detect(dir string) VCS {
+	for _, v := range All {
+		if v.Detect(dir) {
+			return v
+		}
+	}
+	return nil
+}
+
+// run executes name with args in dir (the current directory if dir is
+// empty) and returns its trimmed stdout. A non-zero exit or missing binary
+// is returned as an error.
+func run(dir, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// splitLines splits command output into non-empty, trimmed lines.
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}